@@ -0,0 +1,38 @@
+// Package concurrency provides a small bounded worker pool used to run
+// several volume backups in parallel instead of the historical sequential
+// loop, while keeping the total number of simultaneous duplicity/restic
+// containers under control.
+package concurrency
+
+import "sync"
+
+// Job is a single unit of work submitted to a Pool, typically a closure
+// around a single volume's engine.Backup() call.
+type Job func() error
+
+// Run executes jobs with at most n running concurrently and returns their
+// errors in the same order as jobs, regardless of completion order. A
+// non-positive n runs every job sequentially (n=1).
+func Run(n int, jobs []Job) []error {
+	if n < 1 {
+		n = 1
+	}
+
+	errs := make([]error, len(jobs))
+	sem := make(chan struct{}, n)
+	var wg sync.WaitGroup
+
+	for i, job := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(i int, job Job) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = job()
+		}(i, job)
+	}
+
+	wg.Wait()
+	return errs
+}
@@ -0,0 +1,88 @@
+// Package config defines the handler's runtime configuration: the global
+// defaults every volume falls back to unless it carries its own bivac.* or
+// io.conplicity.* label override.
+package config
+
+// Config stores the handler's configuration.
+type Config struct {
+	Loglevel            string
+	VolumesBlacklist    []string
+	NoVerify            bool
+	JSON                bool
+	Engine              string
+	HostnameFromRancher bool
+
+	// Notify lists the notifier destination URLs (smtp://, slack://,
+	// discord://...) every volume's backup reports to, in addition to any
+	// destination set on the volume's own bivac.notify label.
+	Notify []string
+
+	// Concurrency caps how many volumes BackupVolumes backs up at once.
+	// Zero or negative runs them sequentially, one at a time.
+	Concurrency int
+
+	// DryRun computes and logs the retention plan for every volume without
+	// deleting anything. A volume's own bivac.dry_run label can still
+	// force this on for just that volume.
+	DryRun bool
+
+	Duplicity struct {
+		Image           string
+		FullIfOlderThan string
+		RemoveOlderThan string
+		TargetURL       string
+
+		// TargetTemplate, when set, overrides the historical
+		// TargetURL/hostname/volume layout with a strftime-style rendered
+		// path (see the target package).
+		TargetTemplate string
+
+		// Checksum enables the post-backup checksum pass globally; a
+		// volume's own bivac.checksum label can still enable it alone.
+		Checksum bool
+
+		// BandwidthLimit caps the duplicity container's bandwidth, in
+		// bytes/sec. A volume's own bivac.bandwidth_limit label overrides
+		// it for that volume alone. Empty means no limit.
+		BandwidthLimit string
+
+		// LatestSymlink maintains a "latest" symlink next to the chain
+		// that was just uploaded. Only the file:// backend supports it.
+		LatestSymlink bool
+	}
+
+	RClone struct {
+		Image string
+	}
+
+	Metrics struct {
+		PushgatewayURL string
+	}
+
+	AWS struct {
+		AccessKeyID     string
+		SecretAccessKey string
+	}
+
+	Swift struct {
+		Username   string
+		Password   string
+		AuthURL    string
+		TenantName string
+		RegionName string
+	}
+
+	Docker struct {
+		Endpoint string
+	}
+
+	// Retention configures the grandfather-father-son policy applied to
+	// every volume unless overridden by its own bivac.keep-* labels.
+	Retention struct {
+		KeepHourly  int
+		KeepDaily   int
+		KeepWeekly  int
+		KeepMonthly int
+		KeepYearly  int
+	}
+}
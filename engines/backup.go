@@ -0,0 +1,40 @@
+package engines
+
+import (
+	"sync"
+
+	"github.com/camptocamp/conplicity/concurrency"
+	"github.com/camptocamp/conplicity/handler"
+	"github.com/camptocamp/conplicity/volume"
+)
+
+// BackupVolumes backs up every volume in vols, running up to
+// h.Config.Concurrency of them at a time instead of the historical
+// sequential loop. Each volume gets its own duplicity_cache bind mount
+// (see DuplicityEngine.cacheMount), so concurrent backups can never
+// corrupt one another's cache.
+func BackupVolumes(h *handler.Conplicity, vols []*volume.Volume) (metrics []string, errs []error) {
+	var mu sync.Mutex
+
+	jobs := make([]concurrency.Job, len(vols))
+	for i, vol := range vols {
+		vol := vol
+		jobs[i] = func() error {
+			e := &DuplicityEngine{
+				Handler: h,
+				Volume:  vol,
+			}
+
+			volMetrics, err := e.Backup()
+
+			mu.Lock()
+			metrics = append(metrics, volMetrics...)
+			mu.Unlock()
+
+			return err
+		}
+	}
+
+	errs = concurrency.Run(h.Config.Concurrency, jobs)
+	return
+}
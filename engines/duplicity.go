@@ -4,12 +4,20 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/camptocamp/conplicity/handler"
+	"github.com/camptocamp/conplicity/hook"
+	"github.com/camptocamp/conplicity/notifier"
+	"github.com/camptocamp/conplicity/retention"
+	"github.com/camptocamp/conplicity/target"
 	"github.com/camptocamp/conplicity/util"
 	"github.com/camptocamp/conplicity/volume"
 	"github.com/docker/engine-api/types"
@@ -24,20 +32,70 @@ type DuplicityEngine struct {
 }
 
 // Constants
-const cacheMount = "duplicity_cache:/root/.cache/duplicity"
+const cacheMountPrefix = "duplicity_cache"
 const timeFormat = "Mon Jan 2 15:04:05 2006"
 
 var fullBackupRx = regexp.MustCompile("Last full backup date: (.+)")
 var chainEndTimeRx = regexp.MustCompile("Chain end time: (.+)")
+var relativeRestoreTimeRx = regexp.MustCompile(`^[0-9]+[smhDWMY]$`)
+
+// cacheMus guards access to cacheLock, which hands out one mutex per
+// volume. Each volume gets its own cache bind mount (see cacheMount), so
+// concurrent backups of different volumes (run through the concurrency
+// package) never contend with each other; concurrent operations on the
+// *same* volume's cache still serialize, which is what actually prevents
+// corruption.
+var (
+	cacheMusMu sync.Mutex
+	cacheMus   = map[string]*sync.Mutex{}
+)
+
+// cacheLock returns the mutex guarding volumeName's duplicity cache,
+// creating it on first use.
+func cacheLock(volumeName string) *sync.Mutex {
+	cacheMusMu.Lock()
+	defer cacheMusMu.Unlock()
+
+	mu, ok := cacheMus[volumeName]
+	if !ok {
+		mu = &sync.Mutex{}
+		cacheMus[volumeName] = mu
+	}
+	return mu
+}
+
+// cacheMount returns this engine's duplicity cache bind mount, namespaced
+// per volume so it is safe to back up several volumes concurrently.
+func (d *DuplicityEngine) cacheMount() string {
+	return cacheMountPrefix + "_" + d.Volume.Name + ":/root/.cache/duplicity"
+}
 
 // GetName returns the engine name
 func (*DuplicityEngine) GetName() string {
 	return "Duplicity"
 }
 
+// notify builds the notifiers configured globally and for the volume, then
+// sends event through all of them. A failure to notify is logged but never
+// fails the backup itself.
+func (d *DuplicityEngine) notify(event notifier.Event) {
+	dests := d.Handler.Config.Notify
+	if volDest, _ := util.GetBivacLabel(d.Volume.Volume, "notify"); volDest != "" {
+		dests = append(dests, volDest)
+	}
+	if len(dests) == 0 {
+		return
+	}
+
+	event.Engine = d.GetName()
+	event.Labels = d.Volume.Volume.Labels
+	notifier.NewMulti(dests, nil).Notify(context.Background(), event)
+}
+
 // Backup performs the backup of the passed volume
 func (d *DuplicityEngine) Backup() (metrics []string, err error) {
 	vol := d.Volume
+	startedAt := time.Now()
 	log.WithFields(log.Fields{
 		"volume":     vol.Name,
 		"driver":     vol.Driver,
@@ -54,27 +112,86 @@ func (d *DuplicityEngine) Backup() (metrics []string, err error) {
 		removeOlderThan = d.Handler.Config.Duplicity.RemoveOlderThan
 	}
 
-	pathSeparator := "/"
-	if strings.HasPrefix(d.Handler.Config.Duplicity.TargetURL, "swift://") {
-		// Looks like I'm not the one to fall on this issue: http://stackoverflow.com/questions/27991960/upload-to-swift-pseudo-folders-using-duplicity
-		pathSeparator = "_"
+	targetTemplate := d.Handler.Config.Duplicity.TargetTemplate
+	if targetTemplate == "" {
+		pathSeparator := "/"
+		if strings.HasPrefix(d.Handler.Config.Duplicity.TargetURL, "swift://") {
+			// Looks like I'm not the one to fall on this issue: http://stackoverflow.com/questions/27991960/upload-to-swift-pseudo-folders-using-duplicity
+			pathSeparator = "_"
+		}
+		vol.Target = d.Handler.Config.Duplicity.TargetURL + pathSeparator + d.Handler.Hostname + pathSeparator + vol.Name
+	} else {
+		util.CheckErr(target.Validate(targetTemplate), "Invalid target template for volume "+vol.Name+" : %v", "fatal")
+
+		vol.Target, err = target.Render(targetTemplate, target.Data{
+			Hostname:  d.Handler.Hostname,
+			Volume:    vol.Name,
+			Driver:    vol.Driver,
+			Engine:    d.GetName(),
+			TargetURL: d.Handler.Config.Duplicity.TargetURL,
+			Labels:    vol.Volume.Labels,
+		}, startedAt)
+		util.CheckErr(err, "Failed to render target template for volume "+vol.Name+" : %v", "fatal")
 	}
 
 	backupDir := vol.BackupDir
-	vol.Target = d.Handler.Config.Duplicity.TargetURL + pathSeparator + d.Handler.Hostname + pathSeparator + vol.Name
 	vol.BackupDir = vol.Mountpoint + "/" + backupDir
 	vol.Mount = vol.Name + ":" + vol.Mountpoint + ":ro"
 	vol.FullIfOlderThan = fullIfOlderThan
 	vol.RemoveOlderThan = removeOlderThan
 
+	defer func() {
+		d.notify(notifier.Event{
+			Volume:     vol.Name,
+			Phase:      "backup",
+			StartedAt:  startedAt,
+			FinishedAt: time.Now(),
+			Err:        err,
+			Metrics:    metrics,
+		})
+	}()
+
+	plan, err := hook.ParseLabels(vol.Volume)
+	util.CheckErr(err, "Failed to parse backup hook labels for volume "+vol.Name+" : %v", "fatal")
+
+	if plan.StopContainers {
+		var stopped []string
+		stopped, err = hook.StopContainers(d.Handler, vol.Name, plan.GracePeriod)
+		util.CheckErr(err, "Failed to stop containers using volume "+vol.Name+" : %v", "error")
+		defer hook.StartContainers(d.Handler, stopped)
+	}
+
+	if len(plan.Pre) > 0 {
+		_, err = hook.Run(d.Handler, plan.Pre)
+		util.CheckErr(err, "Failed to run pre-backup hooks for volume "+vol.Name+" : %v", "fatal")
+	}
+
 	var newMetrics []string
 
 	newMetrics, err = d.duplicityBackup()
 	util.CheckErr(err, "Failed to backup volume "+vol.Name+" : %v", "fatal")
 	metrics = append(metrics, newMetrics...)
 
-	_, err = d.removeOld()
+	if err == nil && d.Handler.Config.Duplicity.LatestSymlink {
+		if symlinkErr := d.updateLatestSymlink(); symlinkErr != nil {
+			log.Errorf("Failed to update latest symlink for volume %v: %v", vol.Name, symlinkErr)
+		}
+	}
+
+	if err == nil && len(plan.Post) > 0 {
+		_, err = hook.Run(d.Handler, plan.Post)
+		util.CheckErr(err, "Failed to run post-backup hooks for volume "+vol.Name+" : %v", "fatal")
+	}
+
+	if err != nil && len(plan.OnError) > 0 {
+		if _, hookErr := hook.Run(d.Handler, plan.OnError); hookErr != nil {
+			log.Errorf("Failed to run on-error hooks for volume %v: %v", vol.Name, hookErr)
+		}
+	}
+
+	newMetrics, err = d.removeOld()
 	util.CheckErr(err, "Failed to remove old backups for volume "+vol.Name+" : %v", "fatal")
+	metrics = append(metrics, newMetrics...)
 
 	_, err = d.cleanup()
 	util.CheckErr(err, "Failed to cleanup extraneous duplicity files for volume "+vol.Name+" : %v", "fatal")
@@ -91,6 +208,14 @@ func (d *DuplicityEngine) Backup() (metrics []string, err error) {
 		metrics = append(metrics, newMetrics...)
 	}
 
+	checksumLbl, _ := util.GetBivacLabel(vol.Volume, "checksum")
+	checksum := d.Handler.Config.Duplicity.Checksum || (checksumLbl == "true")
+	if checksum {
+		newMetrics, err = d.checksum()
+		util.CheckErr(err, "Failed to checksum backup for volume "+vol.Name+" : %v", "fatal")
+		metrics = append(metrics, newMetrics...)
+	}
+
 	newMetrics, err = d.status()
 	util.CheckErr(err, "Failed to retrieve last backup info for volume "+vol.Name+" : %v", "fatal")
 	metrics = append(metrics, newMetrics...)
@@ -101,9 +226,102 @@ func (d *DuplicityEngine) Backup() (metrics []string, err error) {
 // removeOld cleans up old backup data
 func (d *DuplicityEngine) removeOld() (metrics []string, err error) {
 	v := d.Volume
-	_, _, err = d.launchDuplicity(
+	startedAt := time.Now()
+	defer func() {
+		d.notify(notifier.Event{
+			Volume:     v.Name,
+			Phase:      "remove-old",
+			StartedAt:  startedAt,
+			FinishedAt: time.Now(),
+			Err:        err,
+			Metrics:    metrics,
+		})
+	}()
+
+	policy := d.retentionPolicy()
+	if policy.IsZero() {
+		// No grandfather-father-son policy configured: fall back to the
+		// historical single-cutoff behavior.
+		_, _, _, err = d.launchDuplicity(
+			[]string{
+				"remove-older-than", v.RemoveOlderThan,
+				"--s3-use-new-style",
+				"--ssh-options", "-oStrictHostKeyChecking=no",
+				"--no-encryption",
+				"--force",
+				"--name", v.Name,
+				v.Target,
+			},
+			[]string{
+				d.cacheMount(),
+			},
+		)
+		util.CheckErr(err, "Failed to launch Duplicity: %v", "fatal")
+		return
+	}
+
+	chains, err := d.chains()
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := policy.Plan(chains, time.Now())
+	counts := retention.Tally(decisions)
+
+	for bucket, kept := range counts.Kept {
+		metrics = append(metrics, fmt.Sprintf("conplicity{volume=\"%v\",what=\"retentionKept\",bucket=\"%v\"} %v", v.Name, bucket, kept))
+	}
+
+	oldestKept, anyKept := retention.OldestKept(decisions)
+
+	// Duplicity has no way to delete arbitrary non-contiguous chains: the
+	// only removal primitive is "remove-older-than <cutoff>". So a chain
+	// the GFS plan marked Keep:false is only actually removed by that call
+	// if it's older than the single oldest chain the plan keeps; anything
+	// Keep:false but newer than oldestKept is a "hole" duplicity can't
+	// prune this run. Count the two separately so the pruned metric
+	// reflects what remove-older-than will really delete, rather than
+	// overclaiming full bucketed GFS semantics.
+	var actuallyPruned, unprunable int
+	for _, dec := range decisions {
+		if dec.Keep {
+			continue
+		}
+		if anyKept && dec.Chain.EndTime.Before(oldestKept) {
+			actuallyPruned++
+		} else {
+			unprunable++
+		}
+	}
+
+	metrics = append(metrics, fmt.Sprintf("conplicity{volume=\"%v\",what=\"retentionPruned\"} %v", v.Name, actuallyPruned))
+	metrics = append(metrics, fmt.Sprintf("conplicity{volume=\"%v\",what=\"retentionUnprunable\"} %v", v.Name, unprunable))
+
+	dryRunLbl, _ := util.GetBivacLabel(v.Volume, "dry_run")
+	dryRun := d.Handler.Config.DryRun || (dryRunLbl == "true")
+
+	log.WithFields(log.Fields{
+		"volume":     v.Name,
+		"kept":       counts.Kept,
+		"pruned":     actuallyPruned,
+		"unprunable": unprunable,
+		"dryRun":     dryRun,
+	}).Info("Computed retention plan")
+
+	if unprunable > 0 {
+		log.WithFields(log.Fields{
+			"volume": v.Name,
+			"count":  unprunable,
+		}).Warn("Some chains are marked for removal but fall after the oldest kept chain; Duplicity's remove-older-than cannot prune them individually and they will be kept")
+	}
+
+	if dryRun || !anyKept || actuallyPruned == 0 {
+		return
+	}
+
+	_, _, _, err = d.launchDuplicity(
 		[]string{
-			"remove-older-than", v.RemoveOlderThan,
+			"remove-older-than", oldestKept.UTC().Format(time.RFC3339),
 			"--s3-use-new-style",
 			"--ssh-options", "-oStrictHostKeyChecking=no",
 			"--no-encryption",
@@ -112,17 +330,100 @@ func (d *DuplicityEngine) removeOld() (metrics []string, err error) {
 			v.Target,
 		},
 		[]string{
-			cacheMount,
+			d.cacheMount(),
 		},
 	)
 	util.CheckErr(err, "Failed to launch Duplicity: %v", "fatal")
 	return
 }
 
+// updateLatestSymlink maintains a "latest" symlink next to the chain that
+// was just uploaded, pointing at it, so downstream tooling always has a
+// stable path to the newest backup. Only the filesystem backend can do
+// this without a round-trip to the remote; SFTP targets are skipped with
+// a warning rather than shelling out to maintain a remote symlink.
+func (d *DuplicityEngine) updateLatestSymlink() error {
+	v := d.Volume
+
+	if !strings.HasPrefix(v.Target, "file://") {
+		log.WithFields(log.Fields{
+			"volume": v.Name,
+			"target": v.Target,
+		}).Warn("LatestSymlink is only supported for the file backend, skipping")
+		return nil
+	}
+
+	path := strings.TrimPrefix(v.Target, "file://")
+	latest := filepath.Join(filepath.Dir(path), "latest")
+
+	if err := os.Remove(latest); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove previous latest symlink: %v", err)
+	}
+	return os.Symlink(path, latest)
+}
+
+// retentionPolicy builds a retention.Policy from per-volume labels,
+// falling back to the global Retention config.
+func (d *DuplicityEngine) retentionPolicy() retention.Policy {
+	v := d.Volume
+	return retention.Policy{
+		KeepHourly:  labelOrConfigInt(v, "keep-hourly", d.Handler.Config.Retention.KeepHourly),
+		KeepDaily:   labelOrConfigInt(v, "keep-daily", d.Handler.Config.Retention.KeepDaily),
+		KeepWeekly:  labelOrConfigInt(v, "keep-weekly", d.Handler.Config.Retention.KeepWeekly),
+		KeepMonthly: labelOrConfigInt(v, "keep-monthly", d.Handler.Config.Retention.KeepMonthly),
+		KeepYearly:  labelOrConfigInt(v, "keep-yearly", d.Handler.Config.Retention.KeepYearly),
+	}
+}
+
+// labelOrConfigInt reads an integer bivac.<key> volume label, falling back
+// to fallback if the label is absent or not a valid integer.
+func labelOrConfigInt(v *volume.Volume, key string, fallback int) int {
+	if lbl, _ := util.GetBivacLabel(v.Volume, key); lbl != "" {
+		if n, err := strconv.Atoi(lbl); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+// chains runs collection-status and parses out the end time of each
+// backup chain duplicity knows about.
+func (d *DuplicityEngine) chains() ([]retention.Chain, error) {
+	v := d.Volume
+	_, stdout, _, err := d.launchDuplicity(
+		[]string{
+			"collection-status",
+			"--s3-use-new-style",
+			"--ssh-options", "-oStrictHostKeyChecking=no",
+			"--no-encryption",
+			"--name", v.Name,
+			v.Target,
+		},
+		[]string{
+			v.Mount,
+			d.cacheMount(),
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backup chains: %v", err)
+	}
+
+	matches := chainEndTimeRx.FindAllStringSubmatch(stdout, -1)
+	chains := make([]retention.Chain, 0, len(matches))
+	for _, m := range matches {
+		t, err := time.Parse(timeFormat, strings.TrimSpace(m[1]))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse chain end time %q: %v", m[1], err)
+		}
+		chains = append(chains, retention.Chain{EndTime: t})
+	}
+	return chains, nil
+}
+
 // cleanup removes old index data from duplicity
 func (d *DuplicityEngine) cleanup() (metrics []string, err error) {
 	v := d.Volume
-	_, _, err = d.launchDuplicity(
+	_, _, _, err = d.launchDuplicity(
 		[]string{
 			"cleanup",
 			"--s3-use-new-style",
@@ -134,7 +435,7 @@ func (d *DuplicityEngine) cleanup() (metrics []string, err error) {
 			v.Target,
 		},
 		[]string{
-			cacheMount,
+			d.cacheMount(),
 		},
 	)
 	util.CheckErr(err, "Failed to launch Duplicity: %v", "fatal")
@@ -144,7 +445,19 @@ func (d *DuplicityEngine) cleanup() (metrics []string, err error) {
 // verify checks that the backup is usable
 func (d *DuplicityEngine) verify() (metrics []string, err error) {
 	v := d.Volume
-	state, _, err := d.launchDuplicity(
+	startedAt := time.Now()
+	defer func() {
+		d.notify(notifier.Event{
+			Volume:     v.Name,
+			Phase:      "verify",
+			StartedAt:  startedAt,
+			FinishedAt: time.Now(),
+			Err:        err,
+			Metrics:    metrics,
+		})
+	}()
+
+	state, _, _, err := d.launchDuplicity(
 		[]string{
 			"verify",
 			"--s3-use-new-style",
@@ -157,7 +470,7 @@ func (d *DuplicityEngine) verify() (metrics []string, err error) {
 		},
 		[]string{
 			v.Mount,
-			cacheMount,
+			d.cacheMount(),
 		},
 	)
 	util.CheckErr(err, "Failed to launch Duplicity: %v", "fatal")
@@ -169,10 +482,66 @@ func (d *DuplicityEngine) verify() (metrics []string, err error) {
 	return
 }
 
+// checksum re-reads the manifest of the backup that was just uploaded and
+// compares its block hashes, as a stronger (and slower) alternative to
+// verify(). It is only run when the volume or the global config asks for
+// it via the "checksum" option, since it downloads the whole chain again.
+func (d *DuplicityEngine) checksum() (metrics []string, err error) {
+	v := d.Volume
+	startedAt := time.Now()
+	defer func() {
+		d.notify(notifier.Event{
+			Volume:     v.Name,
+			Phase:      "checksum",
+			StartedAt:  startedAt,
+			FinishedAt: time.Now(),
+			Err:        err,
+			Metrics:    metrics,
+		})
+	}()
+
+	state, _, _, err := d.launchDuplicity(
+		[]string{
+			"verify",
+			"--compare-data",
+			"--s3-use-new-style",
+			"--ssh-options", "-oStrictHostKeyChecking=no",
+			"--no-encryption",
+			"--allow-source-mismatch",
+			"--name", v.Name,
+			v.Target,
+			v.BackupDir,
+		},
+		[]string{
+			v.Mount,
+			d.cacheMount(),
+		},
+	)
+	util.CheckErr(err, "Failed to launch Duplicity: %v", "fatal")
+
+	metric := fmt.Sprintf("conplicity{volume=\"%v\",what=\"checksumExitCode\"} %v", v.Name, state)
+	metrics = []string{
+		metric,
+	}
+	return
+}
+
 // status gets the latest backup date info from duplicity
 func (d *DuplicityEngine) status() (metrics []string, err error) {
 	v := d.Volume
-	_, stdout, err := d.launchDuplicity(
+	startedAt := time.Now()
+	defer func() {
+		d.notify(notifier.Event{
+			Volume:     v.Name,
+			Phase:      "status",
+			StartedAt:  startedAt,
+			FinishedAt: time.Now(),
+			Err:        err,
+			Metrics:    metrics,
+		})
+	}()
+
+	_, stdout, _, err := d.launchDuplicity(
 		[]string{
 			"collection-status",
 			"--s3-use-new-style",
@@ -183,7 +552,7 @@ func (d *DuplicityEngine) status() (metrics []string, err error) {
 		},
 		[]string{
 			v.Mount,
-			cacheMount,
+			d.cacheMount(),
 		},
 	)
 	util.CheckErr(err, "Failed to launch Duplicity: %v", "fatal")
@@ -229,11 +598,123 @@ func (d *DuplicityEngine) status() (metrics []string, err error) {
 	return
 }
 
-// launchDuplicity starts a duplicity container with given command and binds
-func (d *DuplicityEngine) launchDuplicity(cmd []string, binds []string) (state int, stdout string, err error) {
+// bandwidthLimit returns the bytes/sec cap to apply to the duplicity
+// container, preferring the per-volume "bivac.bandwidth_limit" label over
+// the global Duplicity.BandwidthLimit setting. An empty string means no
+// limit.
+func (d *DuplicityEngine) bandwidthLimit() string {
+	if limit, _ := util.GetBivacLabel(d.Volume.Volume, "bandwidth_limit"); limit != "" {
+		return limit
+	}
+	return d.Handler.Config.Duplicity.BandwidthLimit
+}
+
+// Restore restores the volume as it stood at timestamp into targetDir, a
+// scratch directory on the host that the caller can then swap into place.
+// timestamp accepts duplicity's own relative intervals ("3D", "2h"), an
+// RFC3339 date, or a Unix epoch.
+func (d *DuplicityEngine) Restore(targetDir, timestamp string) (metrics []string, err error) {
+	v := d.Volume
+	startedAt := time.Now()
+	defer func() {
+		d.notify(notifier.Event{
+			Volume:     v.Name,
+			Phase:      "restore",
+			StartedAt:  startedAt,
+			FinishedAt: time.Now(),
+			Err:        err,
+			Metrics:    metrics,
+		})
+	}()
+
+	restoreTime, err := parseRestoreTime(timestamp)
+	if err != nil {
+		return
+	}
+
+	state, _, _, err := d.launchDuplicity(
+		[]string{
+			"restore",
+			"--time", restoreTime,
+			"--s3-use-new-style",
+			"--ssh-options", "-oStrictHostKeyChecking=no",
+			"--no-encryption",
+			"--force",
+			"--name", v.Name,
+			v.Target,
+			"/restore",
+		},
+		[]string{
+			targetDir + ":/restore",
+			d.cacheMount(),
+		},
+	)
+	util.CheckErr(err, "Failed to launch Duplicity: %v", "fatal")
+
+	metric := fmt.Sprintf("conplicity{volume=\"%v\",what=\"restoreExitCode\"} %v", v.Name, state)
+	metrics = []string{
+		metric,
+	}
+	return
+}
+
+// parseRestoreTime normalizes a restore timestamp into the form accepted
+// by duplicity's --time flag: a relative interval is passed through
+// untouched, an RFC3339 date is reformatted, and a Unix epoch is converted
+// to RFC3339.
+func parseRestoreTime(s string) (string, error) {
+	if relativeRestoreTimeRx.MatchString(s) {
+		return s, nil
+	}
+
+	if epoch, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC().Format(time.RFC3339), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.Format(time.RFC3339), nil
+	}
+
+	return "", fmt.Errorf("unrecognized restore timestamp %q: expected RFC3339, a Unix epoch, or a relative interval like \"3D\" or \"2h\"", s)
+}
+
+// launchDuplicity starts a duplicity container with given command and
+// binds. bandwidthMetric is the bandwidthLimitBytesPerSec metric line, set
+// whenever a bandwidth limit applies to this run; callers that report
+// metrics should append it to what they return.
+func (d *DuplicityEngine) launchDuplicity(cmd []string, binds []string) (state int, stdout string, bandwidthMetric string, err error) {
+	cacheBind := d.cacheMount()
+	for _, b := range binds {
+		if b == cacheBind {
+			mu := cacheLock(d.Volume.Name)
+			mu.Lock()
+			defer mu.Unlock()
+			break
+		}
+	}
+
 	util.PullImage(d.Handler.Client, d.Handler.Config.Duplicity.Image)
 	util.CheckErr(err, "Failed to pull image: %v", "fatal")
 
+	var entrypoint []string
+	if limit := d.bandwidthLimit(); limit != "" {
+		limitBytesPerSec, err := strconv.ParseInt(limit, 10, 64)
+		util.CheckErr(err, "Invalid bandwidth limit %q: %v", "fatal")
+
+		// Cap the container's network usage with trickle rather than
+		// teaching duplicity itself about bandwidth, since it has no
+		// such flag. trickle's -d/-u take KB/s, while the limit is
+		// configured and reported in bytes/sec, so convert.
+		limitKBPerSec := strconv.FormatInt(limitBytesPerSec/1024, 10)
+		entrypoint = []string{"trickle", "-s", "-d", limitKBPerSec, "-u", limitKBPerSec, "duplicity"}
+		bandwidthMetric = fmt.Sprintf("conplicity{volume=\"%v\",what=\"bandwidthLimitBytesPerSec\"} %v", d.Volume.Name, limitBytesPerSec)
+		log.WithFields(log.Fields{
+			"volume":        d.Volume.Name,
+			"limitBytes":    limitBytesPerSec,
+			"limitKBPerSec": limitKBPerSec,
+		}).Debug(bandwidthMetric)
+	}
+
 	env := []string{
 		"AWS_ACCESS_KEY_ID=" + d.Handler.Config.AWS.AccessKeyID,
 		"AWS_SECRET_ACCESS_KEY=" + d.Handler.Config.AWS.SecretAccessKey,
@@ -256,6 +737,7 @@ func (d *DuplicityEngine) launchDuplicity(cmd []string, binds []string) (state i
 		context.Background(),
 		&container.Config{
 			Cmd:          cmd,
+			Entrypoint:   entrypoint,
 			Env:          env,
 			Image:        d.Handler.Config.Duplicity.Image,
 			OpenStdin:    true,
@@ -321,7 +803,7 @@ func (d *DuplicityEngine) duplicityBackup() (metrics []string, err error) {
 	// TODO
 	// Init engine
 
-	state, _, err := d.launchDuplicity(
+	state, _, bandwidthMetric, err := d.launchDuplicity(
 		[]string{
 			"--full-if-older-than", v.FullIfOlderThan,
 			"--s3-use-new-style",
@@ -334,7 +816,7 @@ func (d *DuplicityEngine) duplicityBackup() (metrics []string, err error) {
 		},
 		[]string{
 			v.Mount,
-			cacheMount,
+			d.cacheMount(),
 		},
 	)
 	util.CheckErr(err, "Failed to launch Duplicity: %v", "fatal")
@@ -343,5 +825,8 @@ func (d *DuplicityEngine) duplicityBackup() (metrics []string, err error) {
 	metrics = []string{
 		metric,
 	}
+	if bandwidthMetric != "" {
+		metrics = append(metrics, bandwidthMetric)
+	}
 	return
 }
@@ -0,0 +1,17 @@
+// Package handler wires together the Docker client and configuration that
+// every backup engine needs to reach the daemon and honor the operator's
+// settings.
+package handler
+
+import (
+	"github.com/camptocamp/conplicity/config"
+	docker "github.com/docker/engine-api/client"
+)
+
+// Conplicity is the handle duplicity-based engines use to reach the Docker
+// daemon and the operator's configuration.
+type Conplicity struct {
+	*docker.Client
+	Config   *config.Config
+	Hostname string
+}
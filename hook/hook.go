@@ -0,0 +1,227 @@
+// Package hook lets a backup engine stop the containers using a volume for
+// the duration of the backup, and run arbitrary commands inside them right
+// before and after the backup runs. This is how Bivac produces
+// application-consistent backups of databases living in Docker volumes
+// (mysqldump, pg_dumpall, redis-cli SAVE...) without operators having to
+// bolt on external scripts.
+package hook
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/camptocamp/conplicity/util"
+	"github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+)
+
+// DockerClient is the subset of the Docker engine-api client that hook needs
+// to discover and act on containers. *handler.Conplicity satisfies this
+// already; it's declared here, rather than taking *handler.Conplicity
+// directly, so engines that don't carry a full Conplicity handler (like
+// ResticEngine) can drive hooks with a plain *client.Client instead.
+type DockerClient interface {
+	ContainerList(ctx context.Context, options types.ContainerListOptions) ([]types.Container, error)
+	ContainerStop(ctx context.Context, container string, timeout *time.Duration) error
+	ContainerStart(ctx context.Context, container string, options types.ContainerStartOptions) error
+	ContainerExecCreate(ctx context.Context, container string, config types.ExecConfig) (types.ContainerExecCreateResponse, error)
+	ContainerExecAttach(ctx context.Context, execID string, config types.ExecConfig) (types.HijackedResponse, error)
+	ContainerExecInspect(ctx context.Context, execID string) (types.ContainerExecInspect, error)
+}
+
+// DefaultTimeout bounds how long a single hook may run before it is
+// considered failed.
+const DefaultTimeout = 5 * time.Minute
+
+// DefaultGracePeriod is how long ContainerStop waits for a container to
+// exit on its own before killing it.
+const DefaultGracePeriod = 10 * time.Second
+
+// Exec describes a single "docker exec <container> <cmd>" action.
+type Exec struct {
+	Container string
+	Cmd       []string
+	Timeout   time.Duration
+}
+
+// Plan is the set of hooks and stop/restart behaviour discovered for a
+// volume's labels.
+type Plan struct {
+	StopContainers bool
+	GracePeriod    time.Duration
+	Pre            []Exec
+	Post           []Exec
+	OnError        []Exec
+}
+
+// ParseLabels builds a Plan from a volume's Docker labels, looking up
+// bivac.stop-during-backup, bivac.hook.pre-backup, bivac.hook.post-backup
+// and bivac.hook.on-error via util.GetBivacLabel.
+func ParseLabels(vol *types.Volume) (plan Plan, err error) {
+	stop, _ := util.GetBivacLabel(vol, "stop-during-backup")
+	plan.StopContainers = stop == "true"
+	plan.GracePeriod = DefaultGracePeriod
+
+	if plan.Pre, err = parseExecLabel(vol, "hook.pre-backup"); err != nil {
+		return
+	}
+	if plan.Post, err = parseExecLabel(vol, "hook.post-backup"); err != nil {
+		return
+	}
+	if plan.OnError, err = parseExecLabel(vol, "hook.on-error"); err != nil {
+		return
+	}
+	return
+}
+
+// parseExecLabel turns a "<container>:<cmd...>" label value into an Exec.
+func parseExecLabel(vol *types.Volume, key string) ([]Exec, error) {
+	value, _ := util.GetBivacLabel(vol, key)
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid hook label %q, expected \"<container>:<cmd>\"", value)
+	}
+
+	return []Exec{
+		{
+			Container: parts[0],
+			Cmd:       strings.Fields(parts[1]),
+			Timeout:   DefaultTimeout,
+		},
+	}, nil
+}
+
+// StopContainers finds the containers using volumeName and stops them,
+// returning their IDs so they can be restarted afterwards. Errors stopping
+// one container do not prevent stopping the others.
+func StopContainers(h DockerClient, volumeName string, grace time.Duration) (stopped []string, err error) {
+	containers, err := containersUsingVolume(h, volumeName)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range containers {
+		log.WithFields(log.Fields{
+			"volume":    volumeName,
+			"container": c,
+		}).Info("Stopping container for backup")
+
+		if stopErr := h.ContainerStop(context.Background(), c, &grace); stopErr != nil {
+			log.Errorf("Failed to stop container %v: %v", c, stopErr)
+			err = stopErr
+			continue
+		}
+		stopped = append(stopped, c)
+	}
+	return
+}
+
+// StartContainers restarts containers previously stopped by StopContainers.
+// It always attempts every container, even if one restart fails.
+func StartContainers(h DockerClient, containers []string) (err error) {
+	for _, c := range containers {
+		log.WithFields(log.Fields{
+			"container": c,
+		}).Info("Restarting container after backup")
+
+		if startErr := h.ContainerStart(context.Background(), c, types.ContainerStartOptions{}); startErr != nil {
+			log.Errorf("Failed to restart container %v: %v", c, startErr)
+			err = startErr
+		}
+	}
+	return
+}
+
+// containersUsingVolume lists the IDs of containers that mount volumeName.
+func containersUsingVolume(h DockerClient, volumeName string) (ids []string, err error) {
+	containers, err := h.ContainerList(context.Background(), types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %v", err)
+	}
+
+	for _, c := range containers {
+		for _, m := range c.Mounts {
+			if m.Name == volumeName {
+				ids = append(ids, c.ID)
+				break
+			}
+		}
+	}
+	return
+}
+
+// Run executes each Exec in order inside its target container, capturing
+// combined stdout/stderr into the returned log. It stops at the first
+// failing hook.
+func Run(h DockerClient, execs []Exec) (logs string, err error) {
+	var buf bytes.Buffer
+
+	for _, e := range execs {
+		timeout := e.Timeout
+		if timeout == 0 {
+			timeout = DefaultTimeout
+		}
+
+		log.WithFields(log.Fields{
+			"container": e.Container,
+			"command":   strings.Join(e.Cmd, " "),
+		}).Info("Running backup hook")
+
+		output, runErr := execInContainer(h, e.Container, e.Cmd, timeout)
+		buf.WriteString(output)
+
+		if runErr != nil {
+			return buf.String(), fmt.Errorf("hook %q in container %v failed: %v", strings.Join(e.Cmd, " "), e.Container, runErr)
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// execInContainer runs cmd inside container via "docker exec" and returns
+// its combined output.
+func execInContainer(h DockerClient, container string, cmd []string, timeout time.Duration) (output string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	exec, err := h.ContainerExecCreate(ctx, container, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create exec: %v", err)
+	}
+
+	resp, err := h.ContainerExecAttach(ctx, exec.ID, types.ExecConfig{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to attach to exec: %v", err)
+	}
+	defer resp.Close()
+
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(resp.Reader); err != nil {
+		return "", fmt.Errorf("failed to read exec output: %v", err)
+	}
+
+	inspect, err := h.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return buf.String(), fmt.Errorf("failed to inspect exec: %v", err)
+	}
+	if inspect.ExitCode != 0 {
+		return buf.String(), fmt.Errorf("exited with code %v", inspect.ExitCode)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,98 @@
+package hook
+
+import (
+	"testing"
+
+	"github.com/docker/engine-api/types"
+)
+
+func volumeWithLabels(labels map[string]string) *types.Volume {
+	return &types.Volume{Name: "testvol", Labels: labels}
+}
+
+func TestParseLabelsNoLabels(t *testing.T) {
+	plan, err := ParseLabels(volumeWithLabels(nil))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.StopContainers {
+		t.Errorf("expected StopContainers=false with no labels")
+	}
+	if plan.GracePeriod != DefaultGracePeriod {
+		t.Errorf("got GracePeriod=%v, want %v", plan.GracePeriod, DefaultGracePeriod)
+	}
+	if len(plan.Pre) != 0 || len(plan.Post) != 0 || len(plan.OnError) != 0 {
+		t.Errorf("expected no hooks, got %+v", plan)
+	}
+}
+
+func TestParseLabelsStopDuringBackup(t *testing.T) {
+	plan, err := ParseLabels(volumeWithLabels(map[string]string{
+		"bivac.stop-during-backup": "true",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !plan.StopContainers {
+		t.Errorf("expected StopContainers=true")
+	}
+}
+
+func TestParseLabelsHooks(t *testing.T) {
+	plan, err := ParseLabels(volumeWithLabels(map[string]string{
+		"bivac.hook.pre-backup":  "db:pg_dump -U postgres",
+		"bivac.hook.post-backup": "db:echo done",
+		"bivac.hook.on-error":    "db:echo failed",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(plan.Pre) != 1 || plan.Pre[0].Container != "db" {
+		t.Fatalf("got Pre=%+v", plan.Pre)
+	}
+	want := []string{"pg_dump", "-U", "postgres"}
+	if len(plan.Pre[0].Cmd) != len(want) {
+		t.Fatalf("got Pre cmd=%v, want %v", plan.Pre[0].Cmd, want)
+	}
+	for i, w := range want {
+		if plan.Pre[0].Cmd[i] != w {
+			t.Errorf("got Pre cmd[%d]=%v, want %v", i, plan.Pre[0].Cmd[i], w)
+		}
+	}
+
+	if len(plan.Post) != 1 || plan.Post[0].Container != "db" {
+		t.Fatalf("got Post=%+v", plan.Post)
+	}
+	if len(plan.OnError) != 1 || plan.OnError[0].Container != "db" {
+		t.Fatalf("got OnError=%+v", plan.OnError)
+	}
+}
+
+func TestParseLabelsInvalidHook(t *testing.T) {
+	_, err := ParseLabels(volumeWithLabels(map[string]string{
+		"bivac.hook.pre-backup": "not-a-valid-label",
+	}))
+	if err == nil {
+		t.Fatalf("expected an error for a malformed hook label")
+	}
+}
+
+func TestParseExecLabelEmpty(t *testing.T) {
+	execs, err := parseExecLabel(volumeWithLabels(nil), "hook.pre-backup")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if execs != nil {
+		t.Errorf("got %+v, want nil", execs)
+	}
+}
+
+func TestParseExecLabelMissingCommand(t *testing.T) {
+	_, err := parseExecLabel(volumeWithLabels(map[string]string{
+		"bivac.hook.pre-backup": "db:",
+	}), "hook.pre-backup")
+	if err == nil {
+		t.Fatalf("expected an error when the command half is empty")
+	}
+}
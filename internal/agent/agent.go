@@ -5,9 +5,16 @@ import (
 
 	"github.com/camptocamp/bivac/internal/engines"
 	"github.com/camptocamp/bivac/internal/utils"
+	"github.com/camptocamp/conplicity/hook"
+	"github.com/docker/engine-api/types"
 )
 
-func Backup(targetURL, backupPath, hostname string) {
+// Backup runs a restic backup of backupPath. dockerClient and vol are
+// optional: when vol carries bivac.stop-during-backup/bivac.hook.* labels,
+// dockerClient is used to stop the containers using it and run the hooks,
+// exactly as DuplicityEngine does on the conplicity side. Either may be
+// nil, in which case the backup runs with no hooks.
+func Backup(targetURL, backupPath, hostname string, notifyDests []string, dockerClient hook.DockerClient, vol *types.Volume) {
 	e := &engines.ResticEngine{
 		DefaultArgs: []string{
 			"--no-cache",
@@ -15,7 +22,10 @@ func Backup(targetURL, backupPath, hostname string) {
 			"-r",
 			targetURL,
 		},
-		Output: make(map[string]utils.OutputFormat),
+		NotifyDests:  notifyDests,
+		DockerClient: dockerClient,
+		Volume:       vol,
+		Output:       make(map[string]utils.OutputFormat),
 	}
 
 	output := e.Backup(backupPath, hostname)
@@ -23,6 +33,24 @@ func Backup(targetURL, backupPath, hostname string) {
 	return
 }
 
-func Restore(targetURL, backupPath, hostname string) {
-	return
-}
\ No newline at end of file
+// Restore restores snapshotID (or "latest") from targetURL into backupPath,
+// a scratch mount the caller is expected to swap into place once the
+// restore completes.
+func Restore(targetURL, backupPath, hostname, snapshotID string) {
+	e := &engines.ResticEngine{
+		DefaultArgs: []string{
+			"--no-cache",
+			"--json",
+			"-r",
+			targetURL,
+		},
+		Output: make(map[string]utils.OutputFormat),
+	}
+
+	output, err := e.Restore(hostname, snapshotID, backupPath, engines.RestoreOptions{})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(output.Stdout)
+}
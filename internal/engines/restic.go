@@ -0,0 +1,270 @@
+// Package engines implements the bivac agent's backup engines.
+package engines
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/camptocamp/bivac/internal/utils"
+	"github.com/camptocamp/conplicity/concurrency"
+	"github.com/camptocamp/conplicity/hook"
+	"github.com/camptocamp/conplicity/notifier"
+	"github.com/camptocamp/conplicity/util"
+	"github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+)
+
+// ResticEngine implements a backup engine with restic.
+type ResticEngine struct {
+	DefaultArgs []string
+	NotifyDests []string
+	// DockerClient and Volume let Backup discover bivac.stop-during-backup
+	// and bivac.hook.* labels on Volume and act on them the same way
+	// DuplicityEngine does, via the hook package. Both are optional: if
+	// Volume is nil, Backup runs with no hooks, exactly as before.
+	DockerClient hook.DockerClient
+	Volume       *types.Volume
+	// UploadLimit and DownloadLimit cap restic's bandwidth usage in
+	// KiB/s, passed straight through to --limit-upload/--limit-download.
+	// Zero means unlimited. A volume's own bivac.bandwidth_limit label
+	// (bytes/sec, matching DuplicityEngine's convention) overrides both
+	// for that volume alone.
+	UploadLimit   int
+	DownloadLimit int
+	// Checksum enables a post-backup checksum pass distinct from restic's
+	// own default integrity checks, re-reading the just-uploaded data and
+	// comparing it against its recorded hashes. A volume's own
+	// bivac.checksum label can enable it even when this is false.
+	Checksum bool
+	Output   map[string]utils.OutputFormat
+}
+
+// Backup performs a restic backup of backupPath and tags it with hostname.
+// It returns the command's stdout on success, or a description of the
+// failure otherwise.
+func (r *ResticEngine) Backup(backupPath, hostname string) string {
+	startedAt := time.Now()
+
+	var plan hook.Plan
+	if r.Volume != nil {
+		var err error
+		plan, err = hook.ParseLabels(r.Volume)
+		if err != nil {
+			return fmt.Sprintf("invalid backup hook labels: %v", err)
+		}
+	}
+
+	if plan.StopContainers && r.DockerClient != nil {
+		stopped, err := hook.StopContainers(r.DockerClient, r.Volume.Name, plan.GracePeriod)
+		if err != nil {
+			fmt.Println("failed to stop containers:", err)
+		}
+		defer hook.StartContainers(r.DockerClient, stopped)
+	}
+
+	if len(plan.Pre) > 0 {
+		if err := r.runPlanHooks(plan.Pre); err != nil {
+			return fmt.Sprintf("pre-backup hook failed: %v", err)
+		}
+	}
+
+	upload, download := r.resolveBandwidthLimit()
+	args := append(append([]string{}, r.DefaultArgs...), "backup", backupPath, "--host", hostname)
+	args = append(args, r.bandwidthLimitArgs(upload, download)...)
+	out, err := r.run(hostname, args)
+
+	if err == nil && len(plan.Post) > 0 {
+		if hookErr := r.runPlanHooks(plan.Post); hookErr != nil {
+			err = hookErr
+		}
+	}
+
+	if err != nil && len(plan.OnError) > 0 {
+		if hookErr := r.runPlanHooks(plan.OnError); hookErr != nil {
+			fmt.Println("on-error hook failed:", hookErr)
+		}
+	}
+
+	if err == nil && r.resolveChecksum() {
+		if _, checksumErr := r.checksum(hostname); checksumErr != nil {
+			fmt.Println("checksum failed:", checksumErr)
+		}
+	}
+
+	r.notify(notifier.Event{
+		Volume:     backupPath,
+		Phase:      "backup",
+		ExitCode:   out.ExitCode,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+		Stdout:     out.Stdout,
+		Err:        err,
+	})
+
+	if err != nil {
+		return fmt.Sprintf("backup of %v failed: %v\n%v", backupPath, err, out.Stderr)
+	}
+	return out.Stdout
+}
+
+// runPlanHooks runs execs via hook.Run against r.DockerClient. With no
+// DockerClient configured, hook labels were found but there's nothing to
+// execute them with, which is reported as a failure rather than silently
+// skipped.
+func (r *ResticEngine) runPlanHooks(execs []hook.Exec) error {
+	if r.DockerClient == nil {
+		return fmt.Errorf("volume has bivac.hook labels but no Docker client was configured to run them")
+	}
+	_, err := hook.Run(r.DockerClient, execs)
+	return err
+}
+
+// resolveBandwidthLimit returns the upload/download caps (KiB/s) to apply
+// to this run, preferring the volume's own bivac.bandwidth_limit label
+// (bytes/sec) over the engine's configured UploadLimit/DownloadLimit.
+func (r *ResticEngine) resolveBandwidthLimit() (upload, download int) {
+	upload, download = r.UploadLimit, r.DownloadLimit
+	if r.Volume == nil {
+		return
+	}
+	if limit, _ := util.GetBivacLabel(r.Volume, "bandwidth_limit"); limit != "" {
+		if bytesPerSec, err := strconv.Atoi(limit); err == nil {
+			kib := bytesPerSec / 1024
+			upload, download = kib, kib
+		}
+	}
+	return
+}
+
+// resolveChecksum reports whether this run should checksum its upload,
+// preferring the volume's own bivac.checksum label over the engine's
+// Checksum setting.
+func (r *ResticEngine) resolveChecksum() bool {
+	if r.Volume != nil {
+		if lbl, _ := util.GetBivacLabel(r.Volume, "checksum"); lbl != "" {
+			return lbl == "true"
+		}
+	}
+	return r.Checksum
+}
+
+// bandwidthLimitArgs translates upload/download (KiB/s) into restic's
+// native --limit-upload/--limit-download flags.
+func (r *ResticEngine) bandwidthLimitArgs(upload, download int) []string {
+	var args []string
+	if upload > 0 {
+		args = append(args, "--limit-upload", fmt.Sprintf("%d", upload))
+	}
+	if download > 0 {
+		args = append(args, "--limit-download", fmt.Sprintf("%d", download))
+	}
+	return args
+}
+
+// checksum re-reads the data just uploaded for hostname and verifies it
+// against its recorded hashes, as a stronger (and slower) alternative to
+// restic's own default integrity checks. It mirrors
+// DuplicityEngine.checksum.
+func (r *ResticEngine) checksum(hostname string) (out utils.OutputFormat, err error) {
+	args := append(append([]string{}, r.DefaultArgs...), "check", "--read-data")
+	out, err = r.run("checksum:"+hostname, args)
+	if err != nil {
+		return out, fmt.Errorf("checksum failed: %v\n%v", err, out.Stderr)
+	}
+	return out, nil
+}
+
+// VolumeBackup is a single volume to back up with restic: backupPath is the
+// bind-mounted source directory, and vol (optional) carries the bivac.*
+// labels used for hooks and the bandwidth/checksum overrides.
+type VolumeBackup struct {
+	BackupPath string
+	Volume     *types.Volume
+}
+
+// BackupVolumes runs up to maxConcurrency of volumes' restic backups in
+// parallel instead of a sequential loop, mirroring engines.BackupVolumes on
+// the duplicity side. Each volume gets its own ResticEngine (and so its own
+// Output map); uploadLimit, downloadLimit and checksum are the global
+// defaults, overridable per volume via the bivac.bandwidth_limit and
+// bivac.checksum labels. maxConcurrency <= 0 runs every volume sequentially.
+func BackupVolumes(defaultArgs, notifyDests []string, dockerClient hook.DockerClient, uploadLimit, downloadLimit int, checksum bool, maxConcurrency int, hostname string, volumes []VolumeBackup) []string {
+	var mu sync.Mutex
+	var outputs []string
+
+	jobs := make([]concurrency.Job, len(volumes))
+	for i, vb := range volumes {
+		vb := vb
+		jobs[i] = func() error {
+			e := &ResticEngine{
+				DefaultArgs:   defaultArgs,
+				NotifyDests:   notifyDests,
+				DockerClient:  dockerClient,
+				Volume:        vb.Volume,
+				UploadLimit:   uploadLimit,
+				DownloadLimit: downloadLimit,
+				Checksum:      checksum,
+				Output:        make(map[string]utils.OutputFormat),
+			}
+
+			out := e.Backup(vb.BackupPath, hostname)
+
+			mu.Lock()
+			outputs = append(outputs, out)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	concurrency.Run(maxConcurrency, jobs)
+	return outputs
+}
+
+// run executes restic with the given arguments, recording its output under
+// key in r.Output.
+func (r *ResticEngine) run(key string, args []string) (out utils.OutputFormat, err error) {
+	cmd := exec.Command("restic", args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+
+	exitCode := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		exitCode = -1
+	}
+
+	out = utils.OutputFormat{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: exitCode,
+	}
+
+	if r.Output == nil {
+		r.Output = make(map[string]utils.OutputFormat)
+	}
+	r.Output[key] = out
+
+	return
+}
+
+// notify sends event to every notifier destination configured on the
+// engine. A failure to notify never fails the backup itself.
+func (r *ResticEngine) notify(event notifier.Event) {
+	if len(r.NotifyDests) == 0 {
+		return
+	}
+	event.Engine = "Restic"
+	if r.Volume != nil {
+		event.Labels = r.Volume.Labels
+	}
+	notifier.NewMulti(r.NotifyDests, nil).Notify(context.Background(), event)
+}
@@ -0,0 +1,64 @@
+package engines
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/camptocamp/bivac/internal/utils"
+)
+
+// RestoreOptions narrows down what a Restore call pulls out of a snapshot.
+type RestoreOptions struct {
+	Include []string
+	Exclude []string
+}
+
+// Snapshot is the subset of `restic snapshots --json` fields callers need
+// to let an operator pick a point in time to restore.
+type Snapshot struct {
+	ID       string    `json:"id"`
+	ShortID  string    `json:"short_id"`
+	Time     time.Time `json:"time"`
+	Hostname string    `json:"hostname"`
+	Paths    []string  `json:"paths"`
+	Tags     []string  `json:"tags"`
+}
+
+// Snapshots lists the snapshots available in the repository so an operator
+// can choose one to restore.
+func (r *ResticEngine) Snapshots() ([]Snapshot, error) {
+	args := append(append([]string{}, r.DefaultArgs...), "snapshots", "--json")
+	out, err := r.run("snapshots", args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %v\n%v", err, out.Stderr)
+	}
+
+	var snapshots []Snapshot
+	if err := json.Unmarshal([]byte(out.Stdout), &snapshots); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot list: %v", err)
+	}
+	return snapshots, nil
+}
+
+// Restore restores snapshotID (or "latest") for hostname into target,
+// optionally narrowed down by opts.Include/Exclude globs.
+func (r *ResticEngine) Restore(hostname, snapshotID, target string, opts RestoreOptions) (out utils.OutputFormat, err error) {
+	args := append(append([]string{}, r.DefaultArgs...), "restore", snapshotID,
+		"--target", target,
+		"--host", hostname,
+	)
+
+	for _, pattern := range opts.Include {
+		args = append(args, "--include", pattern)
+	}
+	for _, pattern := range opts.Exclude {
+		args = append(args, "--exclude", pattern)
+	}
+
+	out, err = r.run("restore:"+snapshotID, args)
+	if err != nil {
+		return out, fmt.Errorf("failed to restore snapshot %v: %v\n%v", snapshotID, err, out.Stderr)
+	}
+	return out, nil
+}
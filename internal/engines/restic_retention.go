@@ -0,0 +1,45 @@
+package engines
+
+import (
+	"fmt"
+
+	"github.com/camptocamp/bivac/internal/utils"
+	"github.com/camptocamp/conplicity/retention"
+)
+
+// Forget prunes the repository according to policy, translating it
+// directly to restic's native `forget --keep-hourly/--keep-daily/...`
+// flags. With dryRun set, --dry-run is passed instead of --prune so the
+// plan is logged but nothing is deleted.
+func (r *ResticEngine) Forget(policy retention.Policy, dryRun bool) (out utils.OutputFormat, err error) {
+	args := append([]string{}, r.DefaultArgs...)
+	args = append(args, "forget")
+
+	if policy.KeepHourly > 0 {
+		args = append(args, "--keep-hourly", fmt.Sprintf("%d", policy.KeepHourly))
+	}
+	if policy.KeepDaily > 0 {
+		args = append(args, "--keep-daily", fmt.Sprintf("%d", policy.KeepDaily))
+	}
+	if policy.KeepWeekly > 0 {
+		args = append(args, "--keep-weekly", fmt.Sprintf("%d", policy.KeepWeekly))
+	}
+	if policy.KeepMonthly > 0 {
+		args = append(args, "--keep-monthly", fmt.Sprintf("%d", policy.KeepMonthly))
+	}
+	if policy.KeepYearly > 0 {
+		args = append(args, "--keep-yearly", fmt.Sprintf("%d", policy.KeepYearly))
+	}
+
+	if dryRun {
+		args = append(args, "--dry-run")
+	} else {
+		args = append(args, "--prune")
+	}
+
+	out, err = r.run("forget", args)
+	if err != nil {
+		return out, fmt.Errorf("failed to forget snapshots: %v\n%v", err, out.Stderr)
+	}
+	return out, nil
+}
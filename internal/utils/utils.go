@@ -0,0 +1,11 @@
+// Package utils holds small helpers shared by the bivac agent's backup
+// engines.
+package utils
+
+// OutputFormat captures the result of a single command run by an engine
+// (restic, duplicity...) so callers can inspect it without reparsing logs.
+type OutputFormat struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
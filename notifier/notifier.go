@@ -0,0 +1,106 @@
+// Package notifier lets Bivac tell the outside world how a backup went,
+// instead of operators having to scrape the Prometheus metrics it already
+// produces.
+package notifier
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+// Event describes the outcome of a single backup-related operation
+// (a duplicity/restic run, a verify pass, a prune...). It is passed to
+// every configured Notifier so templates can render a message about it.
+type Event struct {
+	Volume     string
+	Labels     map[string]string
+	Engine     string
+	Phase      string
+	ExitCode   int
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Stdout     string
+	Err        error
+	Metrics    []string
+}
+
+// Notifier delivers an Event to some external destination.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// New builds a Notifier for the given destination URL. The scheme selects
+// the backend: smtp://, slack://, discord://, matrix://, pagerduty:// and
+// plain http(s):// for a generic webhook. tmpl may be nil, in which case
+// DefaultTemplate is used.
+func New(dest string, tmpl *Template) (Notifier, error) {
+	if dest == "" {
+		return nil, fmt.Errorf("empty notifier destination")
+	}
+
+	if tmpl == nil {
+		tmpl = DefaultTemplate
+	}
+
+	u, err := url.Parse(dest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse notifier URL %q: %v", dest, err)
+	}
+
+	switch u.Scheme {
+	case "smtp", "smtps":
+		return newSMTPNotifier(u, tmpl)
+	case "slack":
+		return newSlackNotifier(u, tmpl)
+	case "discord":
+		return newDiscordNotifier(u, tmpl)
+	case "matrix":
+		return newMatrixNotifier(u, tmpl)
+	case "pagerduty":
+		return newPagerDutyNotifier(u, tmpl)
+	case "http", "https":
+		return newWebhookNotifier(u, tmpl)
+	default:
+		return nil, fmt.Errorf("unsupported notifier scheme %q", u.Scheme)
+	}
+}
+
+// MultiNotifier fans an Event out to several Notifiers. A failure on one
+// destination is logged and does not prevent the others from running.
+type MultiNotifier []Notifier
+
+// Notify sends the event to every configured destination, collecting but
+// not propagating individual failures.
+func (m MultiNotifier) Notify(ctx context.Context, event Event) (err error) {
+	for _, n := range m {
+		if nErr := n.Notify(ctx, event); nErr != nil {
+			log.WithFields(log.Fields{
+				"volume": event.Volume,
+				"engine": event.Engine,
+				"phase":  event.Phase,
+			}).Errorf("Failed to send notification: %v", nErr)
+			err = nErr
+		}
+	}
+	return
+}
+
+// NewMulti parses a list of destination URLs and returns a single Notifier
+// that fans out to all of them, skipping (and logging) any that fail to
+// parse rather than aborting the whole backup.
+func NewMulti(dests []string, tmpl *Template) Notifier {
+	var notifiers MultiNotifier
+	for _, dest := range dests {
+		n, err := New(dest, tmpl)
+		if err != nil {
+			log.Errorf("Failed to configure notifier %q: %v", dest, err)
+			continue
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers
+}
@@ -0,0 +1,54 @@
+package notifier
+
+import "testing"
+
+func TestNewUnsupportedScheme(t *testing.T) {
+	if _, err := New("ftp://example.com", nil); err == nil {
+		t.Fatalf("expected an error for an unsupported scheme")
+	}
+}
+
+func TestNewEmptyDestination(t *testing.T) {
+	if _, err := New("", nil); err == nil {
+		t.Fatalf("expected an error for an empty destination")
+	}
+}
+
+func TestNewSelectsBackendByScheme(t *testing.T) {
+	cases := []struct {
+		dest    string
+		wantErr bool
+	}{
+		{"smtp://host:25/?to=ops@example.com", false},
+		{"smtp://host:25", true}, // missing ?to=
+		{"slack://hooks.slack.com/services/T000/B000/XXXX", false},
+		{"discord://discord.com/api/webhooks/1/token", false},
+		{"matrix://homeserver/?room=!r:example.org&token=tok", false},
+		{"matrix://homeserver/", true}, // missing ?room=/?token=
+		{"pagerduty://?routing_key=XXXX", false},
+		{"pagerduty://", true}, // missing ?routing_key=
+		{"http://example.com/hook", false},
+		{"https://example.com/hook", false},
+	}
+
+	for _, c := range cases {
+		_, err := New(c.dest, nil)
+		if c.wantErr && err == nil {
+			t.Errorf("New(%q): expected an error, got none", c.dest)
+		}
+		if !c.wantErr && err != nil {
+			t.Errorf("New(%q): unexpected error: %v", c.dest, err)
+		}
+	}
+}
+
+func TestNewMultiSkipsInvalidDestinations(t *testing.T) {
+	n := NewMulti([]string{"ftp://bad", "http://example.com/hook"}, nil)
+	multi, ok := n.(MultiNotifier)
+	if !ok {
+		t.Fatalf("expected a MultiNotifier, got %T", n)
+	}
+	if len(multi) != 1 {
+		t.Fatalf("got %d notifiers, want 1 (the invalid destination should be skipped)", len(multi))
+	}
+}
@@ -0,0 +1,101 @@
+package notifier
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+	"time"
+)
+
+// Template holds the compiled message bodies used to render an Event for
+// the success and failure cases.
+type Template struct {
+	Success *template.Template
+	Failure *template.Template
+}
+
+// DefaultTemplate is used by any Notifier destination that does not
+// override its message body.
+var DefaultTemplate = &Template{
+	Success: template.Must(compile("success", defaultSuccessTemplate)),
+	Failure: template.Must(compile("failure", defaultFailureTemplate)),
+}
+
+const defaultSuccessTemplate = `[{{.Engine}}] Backup of volume "{{.Volume}}" succeeded ({{.Phase}}), took {{duration .StartedAt .FinishedAt}}.`
+
+const defaultFailureTemplate = `[{{.Engine}}] Backup of volume "{{.Volume}}" FAILED ({{.Phase}}, exit code {{.ExitCode}}) after {{duration .StartedAt .FinishedAt}}: {{.Err}}`
+
+var funcMap = template.FuncMap{
+	"formatBytes": formatBytes,
+	"duration":    duration,
+}
+
+// NewTemplate compiles the given success/failure message bodies. Either
+// string may be empty, in which case the corresponding default is kept.
+func NewTemplate(successBody, failureBody string) (*Template, error) {
+	tmpl := &Template{
+		Success: DefaultTemplate.Success,
+		Failure: DefaultTemplate.Failure,
+	}
+
+	if successBody != "" {
+		t, err := compile("success", successBody)
+		if err != nil {
+			return nil, fmt.Errorf("invalid success template: %v", err)
+		}
+		tmpl.Success = t
+	}
+
+	if failureBody != "" {
+		t, err := compile("failure", failureBody)
+		if err != nil {
+			return nil, fmt.Errorf("invalid failure template: %v", err)
+		}
+		tmpl.Failure = t
+	}
+
+	return tmpl, nil
+}
+
+// Render picks the success or failure body depending on the event and
+// executes it.
+func (t *Template) Render(event Event) (string, error) {
+	tpl := t.Success
+	if event.Err != nil || event.ExitCode != 0 {
+		tpl = t.Failure
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("failed to render notification template: %v", err)
+	}
+	return buf.String(), nil
+}
+
+func compile(name, body string) (*template.Template, error) {
+	return template.New(name).Funcs(funcMap).Parse(body)
+}
+
+// formatBytes renders a byte count the way duplicity/restic output does,
+// e.g. 1536 -> "1.5KB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for q := n / unit; q >= unit; q /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// duration renders the elapsed time between two timestamps, rounded to
+// the second so templates don't spew sub-second noise.
+func duration(start, end time.Time) string {
+	if start.IsZero() || end.IsZero() {
+		return "unknown"
+	}
+	return end.Sub(start).Round(time.Second).String()
+}
@@ -0,0 +1,98 @@
+package notifier
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateRenderPicksSuccessOrFailure(t *testing.T) {
+	start := time.Date(2026, 7, 27, 0, 0, 0, 0, time.UTC)
+	end := start.Add(90 * time.Second)
+
+	ok, err := DefaultTemplate.Render(Event{Engine: "Duplicity", Volume: "v1", Phase: "backup", StartedAt: start, FinishedAt: end})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(ok, "succeeded") || strings.Contains(ok, "FAILED") {
+		t.Errorf("got %q, want a success message", ok)
+	}
+
+	failed, err := DefaultTemplate.Render(Event{Engine: "Duplicity", Volume: "v1", Phase: "backup", Err: errors.New("boom"), StartedAt: start, FinishedAt: end})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(failed, "FAILED") || !strings.Contains(failed, "boom") {
+		t.Errorf("got %q, want a failure message mentioning the error", failed)
+	}
+}
+
+func TestTemplateRenderNonZeroExitCodeIsFailure(t *testing.T) {
+	out, err := DefaultTemplate.Render(Event{ExitCode: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "FAILED") {
+		t.Errorf("got %q, want a failure message for a non-zero exit code", out)
+	}
+}
+
+func TestNewTemplateKeepsDefaultsWhenEmpty(t *testing.T) {
+	tmpl, err := NewTemplate("", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tmpl.Success != DefaultTemplate.Success || tmpl.Failure != DefaultTemplate.Failure {
+		t.Errorf("expected empty bodies to keep DefaultTemplate's compiled templates")
+	}
+}
+
+func TestNewTemplateOverridesBody(t *testing.T) {
+	tmpl, err := NewTemplate("all good: {{.Volume}}", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := tmpl.Render(Event{Volume: "v1"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out != "all good: v1" {
+		t.Errorf("got %q, want %q", out, "all good: v1")
+	}
+}
+
+func TestNewTemplateInvalidBody(t *testing.T) {
+	if _, err := NewTemplate("{{.Unclosed", ""); err == nil {
+		t.Fatalf("expected an error for a malformed success template")
+	}
+	if _, err := NewTemplate("", "{{.Unclosed"); err == nil {
+		t.Fatalf("expected an error for a malformed failure template")
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := []struct {
+		in   int64
+		want string
+	}{
+		{512, "512B"},
+		{1536, "1.5KB"},
+		{1048576, "1.0MB"},
+	}
+	for _, c := range cases {
+		if got := formatBytes(c.in); got != c.want {
+			t.Errorf("formatBytes(%d) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	start := time.Date(2026, 7, 27, 0, 0, 0, 0, time.UTC)
+	if got := duration(start, start.Add(90*time.Second)); got != "1m30s" {
+		t.Errorf("got %q, want %q", got, "1m30s")
+	}
+	if got := duration(time.Time{}, start); got != "unknown" {
+		t.Errorf("got %q, want %q for a zero start time", got, "unknown")
+	}
+}
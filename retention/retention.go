@@ -0,0 +1,170 @@
+// Package retention implements a grandfather-father-son style retention
+// policy ("keep the last N hourly/daily/weekly/monthly/yearly backups"),
+// mirroring restic's `forget --keep-hourly/--keep-daily/...` semantics so
+// both engines can share the same policy definition and metrics.
+package retention
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Bucket names a retention rule, used both as a map key and to label the
+// per-bucket metric Bivac emits after a prune.
+type Bucket string
+
+// The buckets are evaluated in this order, from most to least granular.
+const (
+	Hourly  Bucket = "hourly"
+	Daily   Bucket = "daily"
+	Weekly  Bucket = "weekly"
+	Monthly Bucket = "monthly"
+	Yearly  Bucket = "yearly"
+)
+
+var buckets = []Bucket{Hourly, Daily, Weekly, Monthly, Yearly}
+
+// Policy is a grandfather-father-son retention policy: keep the N most
+// recent chains falling in each distinct hour/day/week/month/year.
+type Policy struct {
+	KeepHourly  int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	KeepYearly  int
+}
+
+// IsZero reports whether the policy keeps nothing at all, meaning the
+// caller should fall back to a simpler retention mechanism.
+func (p Policy) IsZero() bool {
+	return p.KeepHourly == 0 && p.KeepDaily == 0 && p.KeepWeekly == 0 && p.KeepMonthly == 0 && p.KeepYearly == 0
+}
+
+func (p Policy) keep(b Bucket) int {
+	switch b {
+	case Hourly:
+		return p.KeepHourly
+	case Daily:
+		return p.KeepDaily
+	case Weekly:
+		return p.KeepWeekly
+	case Monthly:
+		return p.KeepMonthly
+	case Yearly:
+		return p.KeepYearly
+	}
+	return 0
+}
+
+// Chain is one backup chain as reported by `duplicity collection-status`
+// (or one snapshot, in restic's world).
+type Chain struct {
+	EndTime time.Time
+}
+
+// Decision records what the policy decided for a single chain.
+type Decision struct {
+	Chain  Chain
+	Keep   bool
+	Bucket Bucket
+}
+
+// Plan evaluates the policy against chains (which need not be sorted) as
+// of now, returning one Decision per chain.
+func (p Policy) Plan(chains []Chain, now time.Time) []Decision {
+	sorted := make([]Chain, len(chains))
+	copy(sorted, chains)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].EndTime.After(sorted[j].EndTime) })
+
+	decisions := make([]Decision, len(sorted))
+	for i, c := range sorted {
+		decisions[i] = Decision{Chain: c}
+	}
+
+	for _, b := range buckets {
+		limit := p.keep(b)
+		if limit <= 0 {
+			continue
+		}
+
+		seen := map[string]bool{}
+		count := 0
+		for i := range decisions {
+			if count >= limit {
+				break
+			}
+
+			key := bucketKey(b, decisions[i].Chain.EndTime)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			count++
+
+			// A chain already kept by a finer bucket (e.g. Hourly) is also
+			// the newest representative of its own period for this coarser
+			// bucket, so it fills one of limit's slots here too instead of
+			// forcing a second, older chain from the same period to be kept.
+			if !decisions[i].Keep {
+				decisions[i].Keep = true
+				decisions[i].Bucket = b
+			}
+		}
+	}
+
+	return decisions
+}
+
+// bucketKey returns the identifier of the time bucket chain end t falls
+// into for bucket kind b, e.g. "2024-01-15" for Daily.
+func bucketKey(b Bucket, t time.Time) string {
+	switch b {
+	case Hourly:
+		return t.Format("2006-01-02-15")
+	case Daily:
+		return t.Format("2006-01-02")
+	case Weekly:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case Monthly:
+		return t.Format("2006-01")
+	case Yearly:
+		return t.Format("2006")
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Counts tallies how many chains were kept per bucket and how many were
+// pruned overall, for metrics.
+type Counts struct {
+	Kept   map[Bucket]int
+	Pruned int
+}
+
+// Tally summarizes a Plan's decisions.
+func Tally(decisions []Decision) Counts {
+	c := Counts{Kept: map[Bucket]int{}}
+	for _, d := range decisions {
+		if d.Keep {
+			c.Kept[d.Bucket]++
+		} else {
+			c.Pruned++
+		}
+	}
+	return c
+}
+
+// OldestKept returns the EndTime of the oldest chain this plan keeps. It
+// is the cutoff below which a backend with no notion of buckets (like
+// duplicity's remove-older-than) can safely prune everything. ok is false
+// if nothing is kept.
+func OldestKept(decisions []Decision) (oldest time.Time, ok bool) {
+	for _, d := range decisions {
+		if d.Keep && (!ok || d.Chain.EndTime.Before(oldest)) {
+			oldest = d.Chain.EndTime
+			ok = true
+		}
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package retention
+
+import (
+	"testing"
+	"time"
+)
+
+func chainAt(t *testing.T, layout, value string) Chain {
+	t.Helper()
+	ts, err := time.Parse(layout, value)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", value, err)
+	}
+	return Chain{EndTime: ts}
+}
+
+func TestPlanKeepsNewestPerPeriod(t *testing.T) {
+	now := time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC)
+	chains := []Chain{
+		chainAt(t, "2006-01-02", "2024-01-20"),
+		chainAt(t, "2006-01-02", "2024-01-19"),
+		chainAt(t, "2006-01-02", "2024-01-18"),
+		chainAt(t, "2006-01-02", "2024-01-17"),
+	}
+
+	p := Policy{KeepDaily: 2}
+	decisions := p.Plan(chains, now)
+
+	want := map[string]bool{
+		"2024-01-20": true,
+		"2024-01-19": true,
+		"2024-01-18": false,
+		"2024-01-17": false,
+	}
+	for _, d := range decisions {
+		key := d.Chain.EndTime.Format("2006-01-02")
+		if d.Keep != want[key] {
+			t.Errorf("chain %v: got Keep=%v, want %v", key, d.Keep, want[key])
+		}
+	}
+}
+
+func TestPlanCoarserBucketReusesFinerKeptChain(t *testing.T) {
+	// Two chains land in the same day: the newest is kept by Hourly, and
+	// Daily must recognize that chain as its own representative for that
+	// day instead of reaching past it to keep the older one too.
+	now := time.Date(2024, 1, 20, 23, 0, 0, 0, time.UTC)
+	chains := []Chain{
+		chainAt(t, "2006-01-02T15:04:05", "2024-01-20T10:00:00"),
+		chainAt(t, "2006-01-02T15:04:05", "2024-01-20T02:00:00"),
+		chainAt(t, "2006-01-02T15:04:05", "2024-01-19T10:00:00"),
+	}
+
+	p := Policy{KeepHourly: 1, KeepDaily: 2}
+	decisions := p.Plan(chains, now)
+
+	kept := map[string]bool{}
+	for _, d := range decisions {
+		if d.Keep {
+			kept[d.Chain.EndTime.Format(time.RFC3339)] = true
+		}
+	}
+
+	if len(kept) != 2 {
+		t.Fatalf("got %d chains kept, want 2: %v", len(kept), kept)
+	}
+	if !kept["2024-01-20T10:00:00Z"] {
+		t.Errorf("expected the Hourly-kept chain to also satisfy Daily, got %v", kept)
+	}
+	if kept["2024-01-20T02:00:00Z"] {
+		t.Errorf("Daily should not have reached past the Hourly-kept chain to keep a second same-day chain, got %v", kept)
+	}
+	if !kept["2024-01-19T10:00:00Z"] {
+		t.Errorf("expected Daily to keep the prior day's chain too, got %v", kept)
+	}
+}
+
+func TestPlanZeroLimitKeepsNothingForThatBucket(t *testing.T) {
+	now := time.Date(2024, 1, 20, 12, 0, 0, 0, time.UTC)
+	chains := []Chain{chainAt(t, "2006-01-02", "2024-01-20")}
+
+	p := Policy{}
+	decisions := p.Plan(chains, now)
+
+	if decisions[0].Keep {
+		t.Errorf("expected no chain kept when every KeepN is 0")
+	}
+	if !p.IsZero() {
+		t.Errorf("expected IsZero() true for an empty policy")
+	}
+}
+
+func TestTally(t *testing.T) {
+	decisions := []Decision{
+		{Chain: Chain{}, Keep: true, Bucket: Daily},
+		{Chain: Chain{}, Keep: true, Bucket: Daily},
+		{Chain: Chain{}, Keep: true, Bucket: Weekly},
+		{Chain: Chain{}, Keep: false},
+	}
+
+	c := Tally(decisions)
+	if c.Kept[Daily] != 2 {
+		t.Errorf("got Kept[Daily]=%d, want 2", c.Kept[Daily])
+	}
+	if c.Kept[Weekly] != 1 {
+		t.Errorf("got Kept[Weekly]=%d, want 1", c.Kept[Weekly])
+	}
+	if c.Pruned != 1 {
+		t.Errorf("got Pruned=%d, want 1", c.Pruned)
+	}
+}
+
+func TestOldestKept(t *testing.T) {
+	older := chainAt(t, "2006-01-02", "2024-01-01")
+	newer := chainAt(t, "2006-01-02", "2024-01-15")
+
+	decisions := []Decision{
+		{Chain: newer, Keep: true},
+		{Chain: older, Keep: true},
+		{Chain: chainAt(t, "2006-01-02", "2023-12-01"), Keep: false},
+	}
+
+	oldest, ok := OldestKept(decisions)
+	if !ok {
+		t.Fatalf("expected ok=true")
+	}
+	if !oldest.Equal(older.EndTime) {
+		t.Errorf("got oldest=%v, want %v", oldest, older.EndTime)
+	}
+
+	if _, ok := OldestKept(nil); ok {
+		t.Errorf("expected ok=false when nothing is kept")
+	}
+}
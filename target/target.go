@@ -0,0 +1,61 @@
+// Package target expands a backup's destination path from a Go
+// text/template combined with strftime-style time tokens, so operators
+// can lay out their remote storage by date or label instead of Bivac
+// hardcoding <target>/<hostname>/<volume>.
+package target
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Data is what a target template can reference.
+type Data struct {
+	Hostname  string
+	Volume    string
+	Driver    string
+	Engine    string
+	TargetURL string
+	Labels    map[string]string
+}
+
+// strftimeReplacer expands the subset of strftime tokens documented for
+// TargetTemplate. Unsupported tokens are left untouched.
+func strftimeReplacer(at time.Time) *strings.Replacer {
+	return strings.NewReplacer(
+		"%Y", at.Format("2006"),
+		"%m", at.Format("01"),
+		"%d", at.Format("02"),
+		"%H", at.Format("15"),
+		"%M", at.Format("04"),
+		"%S", at.Format("05"),
+	)
+}
+
+// Render expands the strftime tokens in tmplStr against at, then executes
+// it as a Go template against data.
+func Render(tmplStr string, data Data, at time.Time) (string, error) {
+	expanded := strftimeReplacer(at).Replace(tmplStr)
+
+	tpl, err := template.New("target").Parse(expanded)
+	if err != nil {
+		return "", fmt.Errorf("invalid target template %q: %v", tmplStr, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render target template %q: %v", tmplStr, err)
+	}
+	return buf.String(), nil
+}
+
+// Validate parses and executes tmplStr against a representative empty
+// Data so a typo'd template fails at config-load time rather than during
+// the first backup.
+func Validate(tmplStr string) error {
+	_, err := Render(tmplStr, Data{Labels: map[string]string{}}, time.Now())
+	return err
+}
@@ -0,0 +1,64 @@
+package target
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderStrftimeTokens(t *testing.T) {
+	at := time.Date(2026, 7, 27, 9, 5, 3, 0, time.UTC)
+	got, err := Render("backups/%Y/%m/%d-%H%M%S", Data{}, at)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "backups/2026/07/27-090503"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderTemplateFields(t *testing.T) {
+	got, err := Render("s3://bucket/{{.Hostname}}/{{.Volume}}/{{.Labels.env}}", Data{
+		Hostname: "host1",
+		Volume:   "vol1",
+		Labels:   map[string]string{"env": "prod"},
+	}, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "s3://bucket/host1/vol1/prod"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderInvalidTemplate(t *testing.T) {
+	_, err := Render("{{.Unclosed", Data{}, time.Now())
+	if err == nil {
+		t.Fatalf("expected an error for an unparseable template")
+	}
+}
+
+func TestRenderUndefinedField(t *testing.T) {
+	_, err := Render("{{.NoSuchField}}", Data{}, time.Now())
+	if err == nil {
+		t.Fatalf("expected an error referencing an undefined field")
+	}
+}
+
+func TestValidateAcceptsWellFormedTemplate(t *testing.T) {
+	if err := Validate("{{.Hostname}}/{{.Volume}}/%Y"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestValidateRejectsMalformedTemplate(t *testing.T) {
+	err := Validate("{{.Hostname")
+	if err == nil {
+		t.Fatalf("expected an error for a malformed template")
+	}
+	if !strings.Contains(err.Error(), "invalid target template") {
+		t.Errorf("got error %q, want it to mention the template", err)
+	}
+}
@@ -0,0 +1,106 @@
+// Package util holds small helpers shared by the backup engines: error
+// reporting, volume label lookups and Docker image/container plumbing.
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/net/context"
+
+	log "github.com/Sirupsen/logrus"
+	docker "github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+)
+
+const labelPrefix string = "io.conplicity"
+
+// bivacLabelPrefix namespaces the per-volume label overrides Bivac itself
+// introduced (notify, checksum, keep-*, ...), as opposed to the historical
+// io.conplicity namespace above.
+const bivacLabelPrefix string = "bivac"
+
+// CheckErr checks for error, logs and optionally exits the program
+func CheckErr(err error, msg string, level string) {
+	if err != nil {
+		switch level {
+		case "debug":
+			log.Debugf(msg, err)
+		case "info":
+			log.Infof(msg, err)
+		case "warn":
+			log.Warnf(msg, err)
+		case "error":
+			log.Errorf(msg, err)
+		case "fatal":
+			log.Fatalf(msg, err)
+		case "panic":
+			log.Panicf(msg, err)
+		default:
+			log.Panicf("Wrong loglevel '%v', please report this bug", level)
+		}
+	}
+}
+
+// GetVolumeLabel retrieves the value of given key in the io.conplicity
+// namespace of the volume labels
+func GetVolumeLabel(vol *types.Volume, key string) (value string, err error) {
+	value, ok := vol.Labels[labelPrefix+"."+key]
+	if !ok {
+		errMsg := fmt.Sprintf("Key %v not found in labels for volume %v", key, vol.Name)
+		err = errors.New(errMsg)
+	}
+	return
+}
+
+// GetBivacLabel retrieves the value of key in the bivac namespace of the
+// volume labels, e.g. key "checksum" reads the "bivac.checksum" label.
+// Unlike GetVolumeLabel, key is the bare label name with no leading dot.
+func GetBivacLabel(vol *types.Volume, key string) (value string, err error) {
+	value, ok := vol.Labels[bivacLabelPrefix+"."+key]
+	if !ok {
+		errMsg := fmt.Sprintf("Key %v not found in labels for volume %v", key, vol.Name)
+		err = errors.New(errMsg)
+	}
+	return
+}
+
+// PullImage pulls an image from the registry
+func PullImage(c *docker.Client, image string) (err error) {
+	if _, _, err = c.ImageInspectWithRaw(context.Background(), image); err != nil {
+		log.WithFields(log.Fields{
+			"image": image,
+		}).Info("Pulling image")
+		resp, err := c.ImagePull(context.Background(), image, types.ImagePullOptions{})
+		if err != nil {
+			log.Errorf("ImagePull returned an error: %v", err)
+			return err
+		}
+		defer resp.Close()
+		body, err := ioutil.ReadAll(resp)
+		if err != nil {
+			log.Errorf("Failed to read from ImagePull response: %v", err)
+			return err
+		}
+		log.Debugf("Pull image response body: %v", string(body))
+	} else {
+		log.WithFields(log.Fields{
+			"image": image,
+		}).Debug("Image already pulled, not pulling")
+	}
+
+	return nil
+}
+
+// RemoveContainer removes a container
+func RemoveContainer(c *docker.Client, id string) {
+	log.WithFields(log.Fields{
+		"container": id,
+	}).Infof("Removing container")
+	err := c.ContainerRemove(context.Background(), id, types.ContainerRemoveOptions{
+		Force:         true,
+		RemoveVolumes: true,
+	})
+	CheckErr(err, "Failed to remove container "+id+": %v", "error")
+}
@@ -0,0 +1,32 @@
+// Package volume describes a single Docker volume as a backup engine sees
+// it: its Docker metadata plus the target/schedule fields the engine fills
+// in while backing it up.
+package volume
+
+import (
+	"github.com/docker/engine-api/types"
+)
+
+// Volume provides backup methods for a single Docker volume.
+type Volume struct {
+	*types.Volume
+
+	// Target is the destination URL this volume backs up to, either the
+	// historical TargetURL/hostname/volume layout or a rendered
+	// TargetTemplate (see the target package).
+	Target string
+
+	// BackupDir is the path inside the volume's mountpoint that actually
+	// gets backed up.
+	BackupDir string
+
+	// Mount is the bind mount passed to the engine's container, in
+	// "<volume>:<mountpoint>:ro" form.
+	Mount string
+
+	// FullIfOlderThan and RemoveOlderThan are this volume's resolved
+	// duplicity retention settings: the volume's own label if set,
+	// otherwise the global config default.
+	FullIfOlderThan string
+	RemoveOlderThan string
+}